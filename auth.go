@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// runLogin implements the `login` subcommand: it opens a visible browser
+// against a named auth profile so the user can sign in once, after which
+// the profile's cookies/localStorage persist under profileUserDataDir and
+// can be reused by the main screenshot loop via -auth-profile.
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	var profile string
+	fs.StringVar(&profile, "auth-profile", "REQUIRED", "Profile name to persist the session under")
+	fs.Parse(args)
+
+	if profile == "REQUIRED" {
+		exitOnError(errors.New("auth-profile is required"))
+	}
+
+	slog.Info("open browser, sign in then Ctrl+C to quit", "profile", profile)
+
+	// launch headed browser against the named profile
+	debugURL := launcher.New().Headless(false).UserDataDir(profileUserDataDir(profile)).Delete("enable-automation").MustLaunch()
+	browser := rod.New().ControlURL(debugURL).DefaultDevice(devices.Clear).MustConnect()
+	defer browser.MustClose()
+
+	browser.Page(proto.TargetCreateTarget{URL: ""})
+
+	// wait for user to interrupt
+	for true {
+		time.Sleep(5000)
+	}
+}
+
+// profileUserDataDir returns where a named auth profile's browser data
+// (cookies, localStorage, ...) is persisted between `login` and the main
+// screenshot loop.
+func profileUserDataDir(profile string) string {
+	return path.Join(getUserDataDir(), "profiles", profile)
+}
+
+// waitForReady blocks until the ready selector (if configured) is present
+// and the ready JS predicate (if configured) returns true, so the
+// authenticated page has fully rendered rather than still being on a login
+// redirect. It no-ops when neither is configured.
+func waitForReady(pg *rod.Page, selector, js string) error {
+	if selector != "" {
+		if _, e := pg.Element(selector); e != nil {
+			return e
+		}
+	}
+	if js == "" {
+		return nil
+	}
+	for {
+		result, e := pg.Eval(js)
+		if e != nil {
+			return e
+		}
+		if result.Value.Bool() {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// loadCookiesFile imports cookies for headless CI environments, accepting
+// either a Netscape cookies.txt or a JSON array (the shape exported by most
+// browser devtools / extensions).
+func loadCookiesFile(filePath string) ([]*proto.NetworkCookieParam, error) {
+	body, e := os.ReadFile(filePath)
+	if e != nil {
+		return nil, e
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		return parseJSONCookies(body)
+	}
+	return parseNetscapeCookies(body)
+}
+
+type jsonCookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+}
+
+func parseJSONCookies(body []byte) ([]*proto.NetworkCookieParam, error) {
+	var cookies []jsonCookie
+	if e := json.Unmarshal(body, &cookies); e != nil {
+		return nil, e
+	}
+
+	params := make([]*proto.NetworkCookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		params = append(params, &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  proto.TimeSinceEpoch(c.Expires),
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+		})
+	}
+	return params, nil
+}
+
+func parseNetscapeCookies(body []byte) ([]*proto.NetworkCookieParam, error) {
+	var params []*proto.NetworkCookieParam
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#HttpOnly_")) {
+			continue
+		}
+
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			httpOnly = true
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		expires, _ := strconv.ParseFloat(fields[4], 64)
+		params = append(params, &proto.NetworkCookieParam{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   fields[3] == "TRUE",
+			Expires:  proto.TimeSinceEpoch(expires),
+			Name:     fields[5],
+			Value:    fields[6],
+			HTTPOnly: httpOnly,
+		})
+	}
+	if e := scanner.Err(); e != nil {
+		return nil, e
+	}
+	return params, nil
+}
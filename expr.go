@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// whereTokenRe tokenizes a -where expression into quoted strings, the
+// supported operators/parens, and bare words (column names).
+var whereTokenRe = regexp.MustCompile(`'[^']*'|&&|\|\||==|!=|=~|!~|\(|\)|[^\s()]+`)
+
+// whereParser is a small recursive-descent parser/evaluator for -where
+// boolean expressions such as `K =~ 'GPLS-' && D == 'Open'`.
+type whereParser struct {
+	tokens []string
+	pos    int
+	env    map[string]string
+}
+
+// evalWhere evaluates a -where expression against a row's extracted column
+// values. An empty expression always matches.
+func evalWhere(expr string, env map[string]string) (bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, nil
+	}
+	p := &whereParser{tokens: whereTokenRe.FindAllString(expr, -1), env: env}
+	result, e := p.parseOr()
+	if e != nil {
+		return false, e
+	}
+	if p.pos != len(p.tokens) {
+		return false, fmt.Errorf("unexpected token %q in where expression", p.tokens[p.pos])
+	}
+	return result, nil
+}
+
+func (p *whereParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *whereParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *whereParser) parseOr() (bool, error) {
+	left, e := p.parseAnd()
+	if e != nil {
+		return false, e
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, e := p.parseAnd()
+		if e != nil {
+			return false, e
+		}
+		left = left || right
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (bool, error) {
+	left, e := p.parseComparison()
+	if e != nil {
+		return false, e
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, e := p.parseComparison()
+		if e != nil {
+			return false, e
+		}
+		left = left && right
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseComparison() (bool, error) {
+	if p.peek() == "(" {
+		p.next()
+		result, e := p.parseOr()
+		if e != nil {
+			return false, e
+		}
+		if p.next() != ")" {
+			return false, fmt.Errorf("missing closing paren in where expression")
+		}
+		return result, nil
+	}
+
+	leftTok := p.next()
+	if leftTok == "" {
+		return false, fmt.Errorf("unexpected end of where expression")
+	}
+	leftVal := p.resolveOperand(leftTok)
+
+	op := p.next()
+	rightTok := p.next()
+	if rightTok == "" {
+		return false, fmt.Errorf("missing right-hand operand after %q", op)
+	}
+	rightVal := p.resolveOperand(rightTok)
+
+	switch op {
+	case "==":
+		return leftVal == rightVal, nil
+	case "!=":
+		return leftVal != rightVal, nil
+	case "=~":
+		re, e := regexp.Compile(rightVal)
+		if e != nil {
+			return false, e
+		}
+		return re.MatchString(leftVal), nil
+	case "!~":
+		re, e := regexp.Compile(rightVal)
+		if e != nil {
+			return false, e
+		}
+		return !re.MatchString(leftVal), nil
+	default:
+		return false, fmt.Errorf("unknown operator %q in where expression", op)
+	}
+}
+
+func (p *whereParser) resolveOperand(tok string) string {
+	if strings.HasPrefix(tok, "'") && strings.HasSuffix(tok, "'") {
+		return strings.Trim(tok, "'")
+	}
+	return p.env[tok]
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestEvalWhere(t *testing.T) {
+	env := map[string]string{
+		"K": "GPLS-123",
+		"D": "Open",
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    bool
+		wantErr bool
+	}{
+		{name: "empty expression matches", expr: "", want: true},
+		{name: "equals true", expr: "D == 'Open'", want: true},
+		{name: "equals false", expr: "D == 'Closed'", want: false},
+		{name: "not equals", expr: "D != 'Closed'", want: true},
+		{name: "regex match", expr: "K =~ 'GPLS-'", want: true},
+		{name: "regex no match", expr: "K =~ 'JIRA-'", want: false},
+		{name: "regex not match operator", expr: "K !~ 'JIRA-'", want: true},
+		{name: "and both true", expr: "K =~ 'GPLS-' && D == 'Open'", want: true},
+		{name: "and one false", expr: "K =~ 'GPLS-' && D == 'Closed'", want: false},
+		{name: "or one true", expr: "D == 'Closed' || D == 'Open'", want: true},
+		{name: "parens override precedence", expr: "(D == 'Open' || D == 'Closed') && K =~ 'JIRA-'", want: false},
+		{name: "missing field resolves empty", expr: "Z == ''", want: true},
+		{name: "unknown operator", expr: "D ?? 'Open'", wantErr: true},
+		{name: "unbalanced paren", expr: "(D == 'Open'", wantErr: true},
+		{name: "trailing token", expr: "D == 'Open' 'Open'", wantErr: true},
+		{name: "bad regex", expr: "K =~ '('", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, e := evalWhere(tt.expr, env)
+			if tt.wantErr {
+				if e == nil {
+					t.Fatalf("evalWhere(%q) = %v, nil; want error", tt.expr, got)
+				}
+				return
+			}
+			if e != nil {
+				t.Fatalf("evalWhere(%q) unexpected error: %v", tt.expr, e)
+			}
+			if got != tt.want {
+				t.Fatalf("evalWhere(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/go-rod/rod/lib/devices"
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
-	"github.com/go-rod/rod/lib/utils"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -26,6 +25,18 @@ var (
 )
 
 func main() {
+	// dispatch subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "screentest":
+			runScreentest(os.Args[2:])
+			return
+		case "login":
+			runLogin(os.Args[2:])
+			return
+		}
+	}
+
 	// define flags
 	var bookPath string
 	var sheetNumber int
@@ -38,6 +49,21 @@ func main() {
 	var browse bool
 	var debug bool
 	var versionFlag bool
+	var concurrency int
+	var retries int
+	var retryBackoff time.Duration
+	var retryMax time.Duration
+	var authProfile string
+	var cookiesPath string
+	var readySelector string
+	var readyJS string
+	var format string
+	var rules ruleList
+	var rulesFilePath string
+	var pdfPaperSize string
+	var pdfMargin float64
+	var pdfLandscape bool
+	var pdfBackground bool
 
 	// parse flags
 	flag.StringVar(&bookPath, "book", "REQUIRED", "Path to XLSX workbook")
@@ -51,6 +77,21 @@ func main() {
 	flag.BoolVar(&debug, "debug", false, "Show browser window during execution")
 	flag.BoolVar(&browse, "browse", false, "Open browser")
 	flag.BoolVar(&versionFlag, "version", false, "Print version")
+	flag.IntVar(&concurrency, "concurrency", runtime.NumCPU(), "Number of pages to screenshot concurrently")
+	flag.IntVar(&retries, "retries", 2, "Number of retries per issue before giving up")
+	flag.DurationVar(&retryBackoff, "retry-backoff", 500*time.Millisecond, "Initial retry backoff, doubling on each attempt")
+	flag.DurationVar(&retryMax, "retry-backoff-max", 10*time.Second, "Maximum retry backoff")
+	flag.StringVar(&authProfile, "auth-profile", "", "Reuse cookies/localStorage persisted by the login subcommand")
+	flag.StringVar(&cookiesPath, "cookies", "", "Path to a Netscape or JSON cookies file to import before capturing")
+	flag.StringVar(&readySelector, "ready-selector", "", "CSS selector that must appear before a page is considered ready to capture")
+	flag.StringVar(&readyJS, "ready-js", "", "JS expression that must evaluate truthy before a page is considered ready to capture")
+	flag.StringVar(&format, "format", formatViewportPNG, "Output format: viewport-png, fullpage-png, pdf or report")
+	flag.Var(&rules, "rule", "Repeatable capture rule, e.g. \"columns=K;match=GPLS-;template=https://.../{{.K}};out={{.K}}.png\" (overrides -column/-filter/-template)")
+	flag.StringVar(&rulesFilePath, "rules-file", "", "Path to a YAML file of rules, as an alternative to repeating -rule")
+	flag.StringVar(&pdfPaperSize, "pdf-paper-size", "", "Paper size in inches as W,H for -format pdf (default: Chrome's US Letter)")
+	flag.Float64Var(&pdfMargin, "pdf-margin", 0, "Page margin in inches, all sides, for -format pdf (default: Chrome's ~0.4in)")
+	flag.BoolVar(&pdfLandscape, "pdf-landscape", false, "Print -format pdf pages in landscape orientation")
+	flag.BoolVar(&pdfBackground, "pdf-background", false, "Include background graphics in -format pdf output")
 	flag.Parse()
 
 	// print version
@@ -88,8 +129,30 @@ func main() {
 	pageH, e = strconv.Atoi(resolutionSplit[1])
 	exitOnError(e)
 
-	// build filter
-	filterRegex := regexp.MustCompile(filter)
+	switch format {
+	case formatViewportPNG, formatFullpagePNG, formatPDF, formatReport:
+	default:
+		exitOnError(fmt.Errorf("unknown format %q", format))
+	}
+
+	var pdfPaperW, pdfPaperH float64
+	if pdfPaperSize != "" {
+		pdfPaperW, pdfPaperH, e = parsePaperSize(pdfPaperSize)
+		exitOnError(e)
+	}
+
+	if concurrency < 1 {
+		exitOnError(fmt.Errorf("concurrency must be at least 1, got %d", concurrency))
+	}
+	if retries < 0 {
+		exitOnError(fmt.Errorf("retries must be non-negative, got %d", retries))
+	}
+
+	if rulesFilePath != "" {
+		rulesFromFile, e := loadRulesFile(rulesFilePath)
+		exitOnError(e)
+		rules = append(rules, rulesFromFile...)
+	}
 
 	// load book
 	bookFile, e := excelize.OpenFile(bookPath)
@@ -102,30 +165,40 @@ func main() {
 		exitOnError(errors.New("sheet not found"))
 	}
 
-	// extract issues
-	rows, e := bookFile.GetRows(sheetName)
-	exitOnError(e)
+	// extract issues, either via -rule/-rules-file or the legacy single
+	// -column/-filter/-template triple
+	var issueList []issueInput
+	if len(rules) > 0 {
+		issueList, e = buildIssuesFromRules(bookFile, sheetName, rules)
+		exitOnError(e)
+	} else {
+		filterRegex := regexp.MustCompile(filter)
 
-	issues := map[string]struct{}{}
-	for row := 1; row <= len(rows); row++ {
-		// get cell content
-		cellAddr := column + strconv.Itoa(row)
-		cellValue, e := bookFile.GetCellValue(sheetName, cellAddr)
-		if e != nil {
-			slog.Warn("skipping cell", "addr", cellAddr, "error", e)
-			continue
-		}
-		cellValue = strings.TrimSpace(cellValue)
+		rows, e := bookFile.GetRows(sheetName)
+		exitOnError(e)
 
-		// skip unmatch
-		if !filterRegex.MatchString(cellValue) {
-			continue
-		}
+		seen := map[string]bool{}
+		for row := 1; row <= len(rows); row++ {
+			// get cell content
+			cellAddr := column + strconv.Itoa(row)
+			cellValue, e := bookFile.GetCellValue(sheetName, cellAddr)
+			if e != nil {
+				slog.Warn("skipping cell", "addr", cellAddr, "error", e)
+				continue
+			}
+			cellValue = strings.TrimSpace(cellValue)
+
+			// skip unmatch
+			if !filterRegex.MatchString(cellValue) || seen[cellValue] {
+				continue
+			}
+			seen[cellValue] = true
 
-		issues[cellValue] = struct{}{}
+			issueList = append(issueList, issueInput{Value: cellValue, CellAddr: cellAddr})
+		}
 	}
 
-	if len(issues) == 0 {
+	if len(issueList) == 0 {
 		return
 	}
 
@@ -133,52 +206,54 @@ func main() {
 	e = os.MkdirAll(outDirPath, 0755)
 	exitOnError(e)
 
+	// resolve browser user data dir, reusing an auth profile if requested
+	userDataDir := getUserDataDir()
+	if authProfile != "" {
+		userDataDir = profileUserDataDir(authProfile)
+	}
+
+	// load cookies for headless CI environments, if requested
+	var cookies []*proto.NetworkCookieParam
+	if cookiesPath != "" {
+		cookies, e = loadCookiesFile(cookiesPath)
+		exitOnError(e)
+	}
+
 	// create browser
-	debugURL := launcher.New().Headless(!debug).UserDataDir(getUserDataDir()).MustLaunch()
+	debugURL := launcher.New().Headless(!debug).UserDataDir(userDataDir).MustLaunch()
 	browser := rod.New().ControlURL(debugURL).MustConnect()
 	defer browser.MustClose()
-	page := browser.MustPage()
-	defer page.MustClose()
-	e = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{Width: pageW, Height: pageH})
-	exitOnError(e)
 
-	// take snapshots
-	for issue := range issues {
-		// construct url
-		url := strings.ReplaceAll(template, "__VALUE__", issue)
-		// todo: sanitize filename
-		outFileName := issue + ".png"
-		outFilePath := path.Join(outDirPath, outFileName)
-
-		slog.Info("match", "in", issue, "url", url, "out", outFilePath)
-
-		// navigate
-		pageWithTimeout := page.Timeout(time.Duration(timeout) * time.Millisecond)
-		e = pageWithTimeout.WaitStable(1 * time.Second)
-		e = pageWithTimeout.Navigate(url)
-		if e != nil {
-			slog.Warn("skipping screenshot", "url", url, "error", e)
-			continue
-		}
+	// take snapshots, fanned out across a bounded pool of pages
+	results := processIssues(browser, issueList, poolOptions{
+		Concurrency:   concurrency,
+		Retries:       retries,
+		RetryBackoff:  retryBackoff,
+		RetryMax:      retryMax,
+		PageW:         pageW,
+		PageH:         pageH,
+		Timeout:       timeout,
+		Template:      template,
+		OutDirPath:    outDirPath,
+		Cookies:       cookies,
+		ReadySelector: readySelector,
+		ReadyJS:       readyJS,
+		Format:        format,
+		PDF: pdfOptions{
+			Landscape:  pdfLandscape,
+			Background: pdfBackground,
+			PaperW:     pdfPaperW,
+			PaperH:     pdfPaperH,
+			Margin:     pdfMargin,
+		},
+	})
 
-		if e != nil {
-			slog.Warn("skipping screenshot", "url", url, "error", e)
-			continue
-		}
-
-		// capture
-		img, e := pageWithTimeout.Screenshot(false, nil)
-		if e != nil {
-			slog.Warn("skipping screenshot", "url", url, "error", e)
-			continue
-		}
+	e = writeSummary(outDirPath, results)
+	exitOnError(e)
 
-		// persist
-		e = utils.OutputFile(outFilePath, img)
-		if e != nil {
-			slog.Warn("skipping screenshot", "url", url, "error", e)
-			continue
-		}
+	if format == formatReport {
+		e = generateReport(outDirPath, results)
+		exitOnError(e)
 	}
 }
 
@@ -203,3 +278,20 @@ func exitOnError(e error) {
 		os.Exit(1)
 	}
 }
+
+// parsePaperSize parses a "-pdf-paper-size" value of the form "W,H" (inches).
+func parsePaperSize(s string) (float64, float64, error) {
+	split := strings.Split(s, ",")
+	if len(split) != 2 {
+		return 0, 0, fmt.Errorf("invalid pdf paper size %q, expected W,H", s)
+	}
+	w, e := strconv.ParseFloat(strings.TrimSpace(split[0]), 64)
+	if e != nil {
+		return 0, 0, e
+	}
+	h, e := strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+	if e != nil {
+		return 0, 0, e
+	}
+	return w, h, nil
+}
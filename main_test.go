@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestParsePaperSize(t *testing.T) {
+	t.Run("valid size", func(t *testing.T) {
+		w, h, e := parsePaperSize("8.5,11")
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if w != 8.5 || h != 11 {
+			t.Fatalf("parsePaperSize() = %v, %v; want 8.5, 11", w, h)
+		}
+	})
+
+	t.Run("trims whitespace", func(t *testing.T) {
+		w, h, e := parsePaperSize("8.5, 11")
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if w != 8.5 || h != 11 {
+			t.Fatalf("parsePaperSize() = %v, %v; want 8.5, 11", w, h)
+		}
+	})
+
+	t.Run("missing comma is an error", func(t *testing.T) {
+		if _, _, e := parsePaperSize("8.5"); e == nil {
+			t.Fatal("expected error for missing comma")
+		}
+	})
+
+	t.Run("non-numeric is an error", func(t *testing.T) {
+		if _, _, e := parsePaperSize("wide,tall"); e == nil {
+			t.Fatal("expected error for non-numeric size")
+		}
+	})
+}
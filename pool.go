@@ -0,0 +1,305 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// issueInput is one matched cell, carrying enough context to both drive the
+// capture and annotate the eventual report.
+type issueInput struct {
+	Value    string
+	CellAddr string
+
+	// ResolvedURL and OutFileName, when set, override the -template/-out
+	// defaults; they're populated by rule-based extraction (see rules.go).
+	ResolvedURL string
+	OutFileName string
+}
+
+// issueResult is one row of the JSON summary emitted after a pool run.
+type issueResult struct {
+	Issue       string `json:"issue"`
+	CellAddr    string `json:"cellAddr,omitempty"`
+	Status      string `json:"status"` // "ok" or "failed"
+	ElapsedMs   int64  `json:"elapsedMs"`
+	OutPath     string `json:"outPath,omitempty"`
+	ResolvedURL string `json:"resolvedUrl,omitempty"`
+	HTTPStatus  int    `json:"httpStatus,omitempty"`
+	CapturedAt  string `json:"capturedAt,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	imgBytes []byte // only populated for -format report; not persisted to summary.json
+}
+
+// Supported values for the -format flag.
+const (
+	formatViewportPNG = "viewport-png"
+	formatFullpagePNG = "fullpage-png"
+	formatPDF         = "pdf"
+	formatReport      = "report"
+)
+
+// delayTime tracks an exponential backoff delay, doubling on every call to
+// next() up to a configured maximum.
+type delayTime struct {
+	current time.Duration
+	max     time.Duration
+}
+
+func newDelayTime(initial, max time.Duration) *delayTime {
+	return &delayTime{current: initial, max: max}
+}
+
+// next returns the delay to wait before the next retry, then doubles it
+// (capped at max) for the attempt after that.
+func (d *delayTime) next() time.Duration {
+	wait := d.current
+	d.current *= 2
+	if d.current > d.max {
+		d.current = d.max
+	}
+	return wait
+}
+
+// poolOptions configures the concurrent screenshot worker pool.
+type poolOptions struct {
+	Concurrency   int
+	Retries       int
+	RetryBackoff  time.Duration
+	RetryMax      time.Duration
+	PageW, PageH  int
+	Timeout       int
+	Template      string
+	OutDirPath    string
+	Cookies       []*proto.NetworkCookieParam
+	ReadySelector string
+	ReadyJS       string
+	Format        string // "viewport-png" (default), "fullpage-png", "pdf" or "report"
+	PDF           pdfOptions
+}
+
+// pdfOptions configures proto.PagePrintToPDF for -format pdf. A zero value
+// means "let Chrome use its own defaults" (US Letter, 1cm margins).
+type pdfOptions struct {
+	Landscape      bool
+	Background     bool
+	PaperW, PaperH float64 // inches; 0 means Chrome's default
+	Margin         float64 // inches, applied to all four sides; 0 means Chrome's default
+}
+
+// processIssues runs the screenshot capture for every issue through a
+// bounded pool of N rod.Pages taken off the shared browser, retrying each
+// issue with exponential backoff before giving up and recording the
+// failure. It returns one issueResult per issue, in completion order.
+func processIssues(browser *rod.Browser, issues []issueInput, opts poolOptions) []issueResult {
+	issueCh := make(chan issueInput, len(issues))
+	for _, issue := range issues {
+		issueCh <- issue
+	}
+	close(issueCh)
+
+	resultCh := make(chan issueResult, len(issues))
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			page := browser.MustPage()
+			defer page.MustClose()
+			if e := page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{Width: opts.PageW, Height: opts.PageH}); e != nil {
+				slog.Error("failed to set viewport", "error", e)
+				return
+			}
+			if len(opts.Cookies) > 0 {
+				if e := (proto.NetworkSetCookies{Cookies: opts.Cookies}).Call(page); e != nil {
+					slog.Error("failed to set cookies", "error", e)
+					return
+				}
+			}
+			for issue := range issueCh {
+				resultCh <- captureWithRetry(page, issue, opts)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		// issues still queued here were left by workers whose page setup failed
+		for issue := range issueCh {
+			resultCh <- issueResult{Issue: issue.Value, CellAddr: issue.CellAddr, Status: "failed", Error: "no page available: page setup failed"}
+		}
+		close(resultCh)
+	}()
+
+	results := make([]issueResult, 0, len(issues))
+	for result := range resultCh {
+		results = append(results, result)
+	}
+	return results
+}
+
+// captureWithRetry navigates to the issue's URL and captures it, retrying
+// up to opts.Retries times with exponential backoff before giving up.
+func captureWithRetry(page *rod.Page, issue issueInput, opts poolOptions) issueResult {
+	start := time.Now()
+	url := issue.ResolvedURL
+	if url == "" {
+		url = strings.ReplaceAll(opts.Template, "__VALUE__", issue.Value)
+	}
+
+	outFileName := issue.OutFileName
+	if outFileName == "" {
+		ext := "png"
+		if opts.Format == formatPDF {
+			ext = "pdf"
+		}
+		outFileName = issue.Value + "." + ext
+	}
+	outFilePath := path.Join(opts.OutDirPath, sanitizeFileName(outFileName))
+
+	backoff := newDelayTime(opts.RetryBackoff, opts.RetryMax)
+	var lastErr error
+	for attempt := 0; attempt <= opts.Retries; attempt++ {
+		if attempt > 0 {
+			wait := backoff.next()
+			slog.Warn("retrying screenshot", "issue", issue.Value, "attempt", attempt, "wait", wait)
+			time.Sleep(wait)
+		}
+
+		img, httpStatus, e := captureIssue(page, url, opts.Timeout, opts.ReadySelector, opts.ReadyJS, opts.Format, opts.PDF)
+		if e == nil {
+			result := issueResult{
+				Issue:       issue.Value,
+				CellAddr:    issue.CellAddr,
+				Status:      "ok",
+				ElapsedMs:   time.Since(start).Milliseconds(),
+				ResolvedURL: url,
+				HTTPStatus:  httpStatus,
+				CapturedAt:  time.Now().Format(time.RFC3339),
+			}
+			if opts.Format == formatReport {
+				result.imgBytes = img
+				slog.Info("match", "in", issue.Value, "url", url)
+			} else {
+				if e := utils.OutputFile(outFilePath, img); e != nil {
+					lastErr = e
+					continue
+				}
+				result.OutPath = outFilePath
+				slog.Info("match", "in", issue.Value, "url", url, "out", outFilePath)
+			}
+			return result
+		}
+		lastErr = e
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no capture attempt was made (opts.Retries=%d)", opts.Retries)
+	}
+	slog.Warn("giving up on screenshot", "issue", issue.Value, "url", url, "error", lastErr)
+	return issueResult{Issue: issue.Value, CellAddr: issue.CellAddr, Status: "failed", ElapsedMs: time.Since(start).Milliseconds(), ResolvedURL: url, Error: lastErr.Error()}
+}
+
+// nonZeroPtr returns a pointer to v, or nil when v is zero, so callers can
+// leave a PagePrintToPDF field unset (letting Chrome apply its own default)
+// instead of explicitly requesting zero inches.
+func nonZeroPtr(v float64) *float64 {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+// sanitizeFileName strips any directory component from a rendered output
+// filename so workbook content or user templates can't escape OutDirPath
+// via "../" segments.
+func sanitizeFileName(name string) string {
+	return path.Base(path.Clean(name))
+}
+
+// captureIssue navigates to url, waits for the page to be ready, then
+// captures it in the requested format. It returns the raw artifact bytes
+// (a PNG or a PDF, depending on format) plus the HTTP status of the main
+// document response.
+func captureIssue(page *rod.Page, url string, timeout int, readySelector, readyJS, format string, pdf pdfOptions) ([]byte, int, error) {
+	pageWithTimeout := page.Timeout(time.Duration(timeout) * time.Millisecond)
+
+	httpStatus, e := navigateAndGetStatus(pageWithTimeout, url)
+	if e != nil {
+		return nil, 0, e
+	}
+	e = pageWithTimeout.WaitStable(1 * time.Second)
+	if e != nil {
+		return nil, 0, e
+	}
+
+	if readySelector != "" || readyJS != "" {
+		e = waitForReady(pageWithTimeout, readySelector, readyJS)
+		if e != nil {
+			return nil, 0, e
+		}
+	}
+
+	switch format {
+	case formatFullpagePNG:
+		img, e := pageWithTimeout.Screenshot(true, nil)
+		return img, httpStatus, e
+	case formatPDF:
+		result, e := (proto.PagePrintToPDF{
+			Landscape:       pdf.Landscape,
+			PrintBackground: pdf.Background,
+			PaperWidth:      nonZeroPtr(pdf.PaperW),
+			PaperHeight:     nonZeroPtr(pdf.PaperH),
+			MarginTop:       nonZeroPtr(pdf.Margin),
+			MarginBottom:    nonZeroPtr(pdf.Margin),
+			MarginLeft:      nonZeroPtr(pdf.Margin),
+			MarginRight:     nonZeroPtr(pdf.Margin),
+		}).Call(pageWithTimeout)
+		if e != nil {
+			return nil, httpStatus, e
+		}
+		return result.Data, httpStatus, nil
+	default: // formatViewportPNG, formatReport
+		img, e := pageWithTimeout.Screenshot(false, nil)
+		return img, httpStatus, e
+	}
+}
+
+// navigateAndGetStatus navigates to url and returns the HTTP status of the
+// main document response, observed via the network event stream.
+func navigateAndGetStatus(page *rod.Page, url string) (int, error) {
+	var status int
+	wait := page.EachEvent(func(e *proto.NetworkResponseReceived) bool {
+		if e.Type == proto.NetworkResourceTypeDocument {
+			status = e.Response.Status
+			return true
+		}
+		return false
+	})
+	if e := page.Navigate(url); e != nil {
+		return 0, e
+	}
+	wait()
+	return status, nil
+}
+
+// writeSummary persists the per-issue results as out/summary.json.
+func writeSummary(outDirPath string, results []issueResult) error {
+	summaryPath := path.Join(outDirPath, "summary.json")
+	body, e := json.MarshalIndent(results, "", "  ")
+	if e != nil {
+		return e
+	}
+	return os.WriteFile(summaryPath, body, 0644)
+}
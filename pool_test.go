@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain name", in: "GPLS-1.png", want: "GPLS-1.png"},
+		{name: "parent traversal", in: "../../../../tmp/pwned.png", want: "pwned.png"},
+		{name: "absolute path", in: "/etc/passwd", want: "passwd"},
+		{name: "embedded separators", in: "a/b/c.png", want: "c.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFileName(tt.in); got != tt.want {
+				t.Fatalf("sanitizeFileName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonZeroPtr(t *testing.T) {
+	if p := nonZeroPtr(0); p != nil {
+		t.Fatalf("nonZeroPtr(0) = %v, want nil", p)
+	}
+	if p := nonZeroPtr(8.5); p == nil || *p != 8.5 {
+		t.Fatalf("nonZeroPtr(8.5) = %v, want pointer to 8.5", p)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/base64"
+	"html/template"
+	"os"
+	"path"
+)
+
+// reportRow is one entry rendered into report.html.
+type reportRow struct {
+	Issue       string
+	CellAddr    string
+	ResolvedURL string
+	HTTPStatus  int
+	CapturedAt  string
+	Status      string
+	Error       string
+	ImageData   template.URL
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>excel-snip report</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; }
+input#filter { padding: 0.5rem; width: 100%; max-width: 30rem; margin-bottom: 1rem; }
+.row { border: 1px solid #ccc; border-radius: 4px; padding: 1rem; margin-bottom: 1rem; }
+.row.failed { border-color: #c00; }
+.row h2 { margin: 0 0 0.25rem; }
+.meta { color: #555; font-size: 0.9rem; margin-bottom: 0.5rem; }
+img { max-width: 100%; border: 1px solid #ddd; }
+</style>
+</head>
+<body>
+<h1>excel-snip report</h1>
+<input id="filter" type="text" placeholder="Filter by issue, cell or URL...">
+<div id="rows">
+{{range .}}
+<div class="row {{.Status}}" data-search="{{.Issue}} {{.CellAddr}} {{.ResolvedURL}}">
+  <h2>{{.Issue}}</h2>
+  <div class="meta">
+    cell {{.CellAddr}} &middot; {{.ResolvedURL}} &middot; status {{.HTTPStatus}} &middot; captured {{.CapturedAt}}
+    {{if .Error}}&middot; error: {{.Error}}{{end}}
+  </div>
+  {{if .ImageData}}<img src="{{.ImageData}}">{{end}}
+</div>
+{{end}}
+</div>
+<script>
+document.getElementById("filter").addEventListener("input", function (e) {
+  var needle = e.target.value.toLowerCase();
+  document.querySelectorAll("#rows .row").forEach(function (row) {
+    row.style.display = row.dataset.search.toLowerCase().includes(needle) ? "" : "none";
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// generateReport renders a single self-contained report.html into
+// outDirPath, embedding each result's screenshot as a base64 <img>.
+func generateReport(outDirPath string, results []issueResult) error {
+	rows := make([]reportRow, 0, len(results))
+	for _, r := range results {
+		row := reportRow{
+			Issue:       r.Issue,
+			CellAddr:    r.CellAddr,
+			ResolvedURL: r.ResolvedURL,
+			HTTPStatus:  r.HTTPStatus,
+			CapturedAt:  r.CapturedAt,
+			Status:      r.Status,
+			Error:       r.Error,
+		}
+		if len(r.imgBytes) > 0 {
+			row.ImageData = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(r.imgBytes))
+		}
+		rows = append(rows, row)
+	}
+
+	reportPath := path.Join(outDirPath, "report.html")
+	outFile, e := os.Create(reportPath)
+	if e != nil {
+		return e
+	}
+	defer outFile.Close()
+
+	return reportTemplate.Execute(outFile, rows)
+}
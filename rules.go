@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// rule generalizes the single -column/-filter/-template triple into a
+// repeatable capture spec: which columns to read, which row to keep, and
+// how to render the resolved URL and output filename from the extracted
+// column values.
+type rule struct {
+	Sheet    string   `yaml:"sheet"`
+	Columns  []string `yaml:"columns"`
+	Match    string   `yaml:"match"`
+	Template string   `yaml:"template"`
+	Out      string   `yaml:"out"`
+	Where    string   `yaml:"where"`
+}
+
+// ruleList implements flag.Value so -rule can be repeated on the command
+// line, each occurrence parsed as a compact "field=value;field=value" spec.
+type ruleList []rule
+
+func (r *ruleList) String() string {
+	return ""
+}
+
+func (r *ruleList) Set(value string) error {
+	parsed, e := parseRuleSpec(value)
+	if e != nil {
+		return e
+	}
+	*r = append(*r, parsed)
+	return nil
+}
+
+// parseRuleSpec parses a single -rule flag occurrence, e.g.:
+// "sheet=Tickets;columns=K,B;match=GPLS-;template=https://.../{{.K}};out={{.K}}-{{.B|slug}}.png;where=D == 'Open'"
+func parseRuleSpec(spec string) (rule, error) {
+	r := rule{}
+	for _, field := range strings.Split(spec, ";") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		k, v, found := strings.Cut(field, "=")
+		if !found {
+			return rule{}, fmt.Errorf("invalid rule field %q, expected key=value", field)
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		switch k {
+		case "sheet":
+			r.Sheet = v
+		case "columns", "column":
+			for _, col := range strings.Split(v, ",") {
+				r.Columns = append(r.Columns, strings.TrimSpace(col))
+			}
+		case "match":
+			r.Match = v
+		case "template":
+			r.Template = v
+		case "out":
+			r.Out = v
+		case "where":
+			r.Where = v
+		default:
+			return rule{}, fmt.Errorf("unknown rule field %q", k)
+		}
+	}
+	return r, validateRule(r)
+}
+
+// loadRulesFile reads a YAML rules file of the form `rules: [...]`, each
+// entry shaped like rule, as an alternative to repeating -rule.
+func loadRulesFile(filePath string) ([]rule, error) {
+	body, e := os.ReadFile(filePath)
+	if e != nil {
+		return nil, e
+	}
+
+	var doc struct {
+		Rules []rule `yaml:"rules"`
+	}
+	if e := yaml.Unmarshal(body, &doc); e != nil {
+		return nil, e
+	}
+	for _, r := range doc.Rules {
+		if e := validateRule(r); e != nil {
+			return nil, e
+		}
+	}
+	return doc.Rules, nil
+}
+
+func validateRule(r rule) error {
+	if len(r.Columns) == 0 {
+		return fmt.Errorf("rule requires at least one column")
+	}
+	if r.Template == "" {
+		return fmt.Errorf("rule requires a template")
+	}
+	if r.Out == "" {
+		return fmt.Errorf("rule requires an out filename template")
+	}
+	return nil
+}
+
+// templateFuncs are available to rule templates, e.g. {{.B|slug}}.
+var templateFuncs = template.FuncMap{
+	"slug": slugify,
+}
+
+func slugify(s string) string {
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastDash = false
+		case !lastDash:
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func renderTemplate(name, text string, env map[string]string) (string, error) {
+	tmpl, e := template.New(name).Funcs(templateFuncs).Parse(text)
+	if e != nil {
+		return "", e
+	}
+	var out strings.Builder
+	if e := tmpl.Execute(&out, env); e != nil {
+		return "", e
+	}
+	return out.String(), nil
+}
+
+// buildIssuesFromRules applies each rule against the workbook, extracting
+// one issueInput per matching row whose resolved URL hasn't already been
+// produced by an earlier row of this or an earlier rule.
+func buildIssuesFromRules(bookFile *excelize.File, defaultSheetName string, rules []rule) ([]issueInput, error) {
+	seenURLs := map[string]struct{}{}
+	var issues []issueInput
+
+	for ruleIdx, r := range rules {
+		sheetName := r.Sheet
+		if sheetName == "" {
+			sheetName = defaultSheetName
+		}
+
+		rows, e := bookFile.GetRows(sheetName)
+		if e != nil {
+			return nil, fmt.Errorf("rule %d: %w", ruleIdx, e)
+		}
+
+		var matchRegex *regexp.Regexp
+		if r.Match != "" {
+			matchRegex, e = regexp.Compile(r.Match)
+			if e != nil {
+				return nil, fmt.Errorf("rule %d: %w", ruleIdx, e)
+			}
+		}
+
+		for row := 1; row <= len(rows); row++ {
+			env := map[string]string{}
+			for _, col := range r.Columns {
+				cellAddr := col + strconv.Itoa(row)
+				val, e := bookFile.GetCellValue(sheetName, cellAddr)
+				if e != nil {
+					slog.Warn("skipping cell", "addr", cellAddr, "error", e)
+					continue
+				}
+				env[col] = strings.TrimSpace(val)
+			}
+
+			primaryCol := r.Columns[0]
+			primaryVal := env[primaryCol]
+			if matchRegex != nil && !matchRegex.MatchString(primaryVal) {
+				continue
+			}
+
+			matchedWhere, e := evalWhere(r.Where, env)
+			if e != nil {
+				return nil, fmt.Errorf("rule %d: where: %w", ruleIdx, e)
+			}
+			if !matchedWhere {
+				continue
+			}
+
+			resolvedURL, e := renderTemplate("template", r.Template, env)
+			if e != nil {
+				return nil, fmt.Errorf("rule %d: template: %w", ruleIdx, e)
+			}
+			if _, dup := seenURLs[resolvedURL]; dup {
+				continue
+			}
+			seenURLs[resolvedURL] = struct{}{}
+
+			outFileName, e := renderTemplate("out", r.Out, env)
+			if e != nil {
+				return nil, fmt.Errorf("rule %d: out: %w", ruleIdx, e)
+			}
+
+			issues = append(issues, issueInput{
+				Value:       primaryVal,
+				CellAddr:    primaryCol + strconv.Itoa(row),
+				ResolvedURL: resolvedURL,
+				OutFileName: outFileName,
+			})
+		}
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestParseRuleSpec(t *testing.T) {
+	t.Run("full spec", func(t *testing.T) {
+		r, e := parseRuleSpec("sheet=Tickets;columns=K,B;match=GPLS-;template=https://example.com/{{.K}};out={{.K}}-{{.B|slug}}.png;where=D == 'Open'")
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		want := rule{
+			Sheet:    "Tickets",
+			Columns:  []string{"K", "B"},
+			Match:    "GPLS-",
+			Template: "https://example.com/{{.K}}",
+			Out:      "{{.K}}-{{.B|slug}}.png",
+			Where:    "D == 'Open'",
+		}
+		if !reflect.DeepEqual(r, want) {
+			t.Fatalf("parseRuleSpec() = %+v, want %+v", r, want)
+		}
+	})
+
+	t.Run("column alias", func(t *testing.T) {
+		r, e := parseRuleSpec("column=K;template=https://example.com/{{.K}};out={{.K}}.png")
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if !reflect.DeepEqual(r.Columns, []string{"K"}) {
+			t.Fatalf("columns = %v, want [K]", r.Columns)
+		}
+	})
+
+	t.Run("columns with spaces after comma are trimmed", func(t *testing.T) {
+		r, e := parseRuleSpec("columns=K, B;template=t;out=o")
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if !reflect.DeepEqual(r.Columns, []string{"K", "B"}) {
+			t.Fatalf("columns = %v, want [K B]", r.Columns)
+		}
+	})
+
+	t.Run("missing columns", func(t *testing.T) {
+		if _, e := parseRuleSpec("template=t;out=o"); e == nil {
+			t.Fatal("expected error for missing columns")
+		}
+	})
+
+	t.Run("missing template", func(t *testing.T) {
+		if _, e := parseRuleSpec("columns=K;out=o"); e == nil {
+			t.Fatal("expected error for missing template")
+		}
+	})
+
+	t.Run("missing out", func(t *testing.T) {
+		if _, e := parseRuleSpec("columns=K;template=t"); e == nil {
+			t.Fatal("expected error for missing out")
+		}
+	})
+
+	t.Run("malformed field", func(t *testing.T) {
+		if _, e := parseRuleSpec("columns"); e == nil {
+			t.Fatal("expected error for field without '='")
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		if _, e := parseRuleSpec("columns=K;template=t;out=o;bogus=x"); e == nil {
+			t.Fatal("expected error for unknown field")
+		}
+	})
+}
+
+func TestBuildIssuesFromRules(t *testing.T) {
+	book := excelize.NewFile()
+	sheet := "Sheet1"
+	// Columns A and B hold the ticket key and its status; no header row,
+	// since buildIssuesFromRules treats every row as data.
+	rows := [][]interface{}{
+		{"GPLS-1", "Open"},
+		{"GPLS-2", "Closed"},
+		{"JIRA-1", "Open"},
+	}
+	for i, row := range rows {
+		if e := book.SetSheetRow(sheet, "A"+strconv.Itoa(i+1), &row); e != nil {
+			t.Fatalf("failed to seed sheet: %v", e)
+		}
+	}
+
+	rules := []rule{
+		{
+			Columns:  []string{"A", "B"},
+			Match:    "GPLS-",
+			Template: "https://example.com/{{.A}}",
+			Out:      "{{.A}}.png",
+			Where:    "B == 'Open'",
+		},
+	}
+
+	issues, e := buildIssuesFromRules(book, sheet, rules)
+	if e != nil {
+		t.Fatalf("unexpected error: %v", e)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1: %+v", len(issues), issues)
+	}
+	if issues[0].Value != "GPLS-1" || issues[0].ResolvedURL != "https://example.com/GPLS-1" || issues[0].OutFileName != "GPLS-1.png" {
+		t.Fatalf("unexpected issue: %+v", issues[0])
+	}
+}
+
+func TestBuildIssuesFromRulesDedupesAcrossRules(t *testing.T) {
+	book := excelize.NewFile()
+	sheet := "Sheet1"
+	rows := [][]interface{}{
+		{"GPLS-1"},
+	}
+	for i, row := range rows {
+		if e := book.SetSheetRow(sheet, "A"+strconv.Itoa(i+1), &row); e != nil {
+			t.Fatalf("failed to seed sheet: %v", e)
+		}
+	}
+
+	rules := []rule{
+		{Columns: []string{"A"}, Template: "https://example.com/{{.A}}", Out: "{{.A}}.png"},
+		{Columns: []string{"A"}, Template: "https://example.com/{{.A}}", Out: "{{.A}}-again.png"},
+	}
+
+	issues, e := buildIssuesFromRules(book, sheet, rules)
+	if e != nil {
+		t.Fatalf("unexpected error: %v", e)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("got %d issues, want 1 (second rule should be deduped by URL): %+v", len(issues), issues)
+	}
+}
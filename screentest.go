@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/ysmood/gson"
+)
+
+// captureSpec describes what part of the page a testcase should capture.
+type captureSpec struct {
+	Kind     string // "fullscreen", "viewport" or "element"
+	Selector string // only set when Kind == "element"
+}
+
+// testcase is a single compare step parsed out of a screentest script.
+type testcase struct {
+	Name      string
+	Origins   [2]string
+	CacheFlag [2]bool
+	Path      string
+	Headers   http.Header
+	Capture   captureSpec
+	Window    image.Point
+	EvalJS    string
+}
+
+// diffResult is the metrics record produced for one testcase.
+type diffResult struct {
+	Name             string  `json:"name"`
+	DimensionsDiffer bool    `json:"dimensionsDiffer"`
+	DiffPixels       int     `json:"diffPixels"`
+	TotalPixels      int     `json:"totalPixels"`
+	Percent          float64 `json:"percent"`
+	OutPath          string  `json:"outPath"`
+}
+
+// runScreentest implements the `screentest` subcommand: it runs a script of
+// testcases that each load the same path under two origins, screenshots
+// both, and writes a third PNG highlighting the per-pixel differences.
+func runScreentest(args []string) {
+	fs := flag.NewFlagSet("screentest", flag.ExitOnError)
+	var scriptPath string
+	var outDirPath string
+	var threshold float64
+	var timeout int
+	var debug bool
+	fs.StringVar(&scriptPath, "script", "REQUIRED", "Path to screentest script")
+	fs.StringVar(&outDirPath, "out", "out", "Path to output directory")
+	fs.Float64Var(&threshold, "threshold", 0.01, "Fail (non-zero exit) when diff percent exceeds this fraction")
+	fs.IntVar(&timeout, "timeout", 60_000, "Browser page timeout (ms)")
+	fs.BoolVar(&debug, "debug", false, "Show browser window during execution")
+	fs.Parse(args)
+
+	scriptFile, e := os.Open(scriptPath)
+	exitOnError(e)
+	testcases, e := parseScript(scriptFile)
+	scriptFile.Close()
+	exitOnError(e)
+
+	if len(testcases) == 0 {
+		slog.Warn("no testcases found in script")
+		return
+	}
+
+	e = os.MkdirAll(outDirPath, 0755)
+	exitOnError(e)
+	cacheDirPath := path.Join(outDirPath, "cache")
+	e = os.MkdirAll(cacheDirPath, 0755)
+	exitOnError(e)
+
+	debugURL := launcher.New().Headless(!debug).UserDataDir(getUserDataDir()).MustLaunch()
+	browser := rod.New().ControlURL(debugURL).MustConnect()
+	defer browser.MustClose()
+
+	results := make([]diffResult, 0, len(testcases))
+	failures := 0
+	for i, tc := range testcases {
+		name := tc.Name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+
+		imgs := [2][]byte{}
+		for side := 0; side < 2; side++ {
+			img, e := captureOrCached(browser, cacheDirPath, tc, side, timeout)
+			if e != nil {
+				slog.Warn("skipping testcase", "name", name, "origin", tc.Origins[side], "error", e)
+				imgs[side] = nil
+				continue
+			}
+			imgs[side] = img
+		}
+		if imgs[0] == nil || imgs[1] == nil {
+			failures++
+			continue
+		}
+
+		outFilePath := path.Join(outDirPath, name+".diff.png")
+		result, e := diffPNGs(imgs[0], imgs[1], outFilePath)
+		if e != nil {
+			slog.Warn("skipping diff", "name", name, "error", e)
+			failures++
+			continue
+		}
+		result.Name = name
+		results = append(results, result)
+
+		slog.Info("compared", "name", name, "diffPixels", result.DiffPixels, "percent", result.Percent, "out", outFilePath)
+		if result.Percent > threshold {
+			failures++
+		}
+	}
+
+	if failures > 0 {
+		slog.Error("screentest found failing comparisons", "failures", failures, "total", len(testcases))
+		os.Exit(1)
+	}
+}
+
+// captureOrCached loads tc.Path under tc.Origins[side] and returns the PNG
+// bytes for the configured capture, reusing a cached PNG on disk when the
+// origin was marked with a `::cache` suffix and a cache entry already exists
+// for this origin+path+viewport+capture combination.
+func captureOrCached(browser *rod.Browser, cacheDirPath string, tc testcase, side int, timeout int) ([]byte, error) {
+	origin := tc.Origins[side]
+	cacheKeyParts := strings.Join([]string{origin, tc.Path, tc.Window.String(), tc.Capture.Kind, tc.Capture.Selector}, "|")
+	sum := sha1.Sum([]byte(cacheKeyParts))
+	cachePath := path.Join(cacheDirPath, hex.EncodeToString(sum[:])+".png")
+
+	if tc.CacheFlag[side] {
+		if img, e := os.ReadFile(cachePath); e == nil {
+			return img, nil
+		}
+	}
+
+	img, e := capturePage(browser, tc, origin, timeout)
+	if e != nil {
+		return nil, e
+	}
+
+	if tc.CacheFlag[side] {
+		if e := utils.OutputFile(cachePath, img); e != nil {
+			slog.Warn("failed to write cache", "path", cachePath, "error", e)
+		}
+	}
+	return img, nil
+}
+
+func capturePage(browser *rod.Browser, tc testcase, origin string, timeout int) ([]byte, error) {
+	target, e := url.Parse(origin)
+	if e != nil {
+		return nil, e
+	}
+	target.Path = tc.Path
+
+	page := browser.MustPage()
+	defer page.MustClose()
+
+	if len(tc.Headers) > 0 {
+		if e := (proto.NetworkSetExtraHTTPHeaders{Headers: headersToProto(tc.Headers)}).Call(page); e != nil {
+			return nil, e
+		}
+	}
+
+	window := tc.Window
+	if window.X == 0 || window.Y == 0 {
+		window = image.Point{X: 1920, Y: 1080}
+	}
+	e = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{Width: window.X, Height: window.Y})
+	if e != nil {
+		return nil, e
+	}
+
+	pageWithTimeout := page.Timeout(time.Duration(timeout) * time.Millisecond)
+	e = pageWithTimeout.Navigate(target.String())
+	if e != nil {
+		return nil, e
+	}
+	e = pageWithTimeout.WaitStable(1 * time.Second)
+	if e != nil {
+		return nil, e
+	}
+
+	if tc.EvalJS != "" {
+		_, e = pageWithTimeout.Eval(tc.EvalJS)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	switch tc.Capture.Kind {
+	case "element":
+		el, e := pageWithTimeout.Element(tc.Capture.Selector)
+		if e != nil {
+			return nil, e
+		}
+		return el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+	case "fullscreen":
+		return pageWithTimeout.Screenshot(true, nil)
+	default: // "viewport"
+		return pageWithTimeout.Screenshot(false, nil)
+	}
+}
+
+func headersToProto(h http.Header) proto.NetworkHeaders {
+	m := proto.NetworkHeaders{}
+	for k, values := range h {
+		if len(values) > 0 {
+			m[k] = gson.New(values[0])
+		}
+	}
+	return m
+}
+
+// parseScript reads a screentest script into a list of testcases. Blank
+// lines and lines starting with # are skipped. Keywords: `windowsize WxH`,
+// `compare ORIGIN_A ORIGIN_B` (starts a new testcase; an origin may carry a
+// `::cache` suffix), `pathname PATH`, `header Key: Value`,
+// `capture fullscreen|viewport|element SELECTOR` and `eval JS`.
+func parseScript(r *os.File) ([]testcase, error) {
+	var result []testcase
+	var current *testcase
+	defaultWindow := image.Point{X: 1920, Y: 1080}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		keyword := fields[0]
+
+		switch keyword {
+		case "windowsize":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: windowsize expects WxH", lineNo)
+			}
+			w, h, e := parseWindowSize(fields[1])
+			if e != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, e)
+			}
+			defaultWindow = image.Point{X: w, Y: h}
+			if current != nil {
+				current.Window = defaultWindow
+			}
+
+		case "compare":
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %d: compare expects two origins", lineNo)
+			}
+			if current != nil {
+				result = append(result, *current)
+			}
+			originA, cacheA := splitCacheSuffix(fields[1])
+			originB, cacheB := splitCacheSuffix(fields[2])
+			current = &testcase{
+				Name:      strconv.Itoa(len(result) + 1),
+				Origins:   [2]string{originA, originB},
+				CacheFlag: [2]bool{cacheA, cacheB},
+				Headers:   http.Header{},
+				Capture:   captureSpec{Kind: "viewport"},
+				Window:    defaultWindow,
+			}
+
+		case "pathname":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: pathname before compare", lineNo)
+			}
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: pathname expects a path", lineNo)
+			}
+			current.Path = fields[1]
+
+		case "header":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: header before compare", lineNo)
+			}
+			rest := strings.TrimSpace(strings.TrimPrefix(line, keyword))
+			k, v, found := strings.Cut(rest, ":")
+			if !found {
+				return nil, fmt.Errorf("line %d: header expects 'Key: Value'", lineNo)
+			}
+			current.Headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+
+		case "capture":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: capture before compare", lineNo)
+			}
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: capture expects a kind", lineNo)
+			}
+			kind := fields[1]
+			if kind != "fullscreen" && kind != "viewport" && kind != "element" {
+				return nil, fmt.Errorf("line %d: unknown capture kind %q", lineNo, kind)
+			}
+			selector := ""
+			if kind == "element" {
+				if len(fields) != 3 {
+					return nil, fmt.Errorf("line %d: capture element expects a selector", lineNo)
+				}
+				selector = fields[2]
+			}
+			current.Capture = captureSpec{Kind: kind, Selector: selector}
+
+		case "eval":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: eval before compare", lineNo)
+			}
+			current.EvalJS = strings.TrimSpace(strings.TrimPrefix(line, keyword))
+
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", lineNo, keyword)
+		}
+	}
+	if e := scanner.Err(); e != nil {
+		return nil, e
+	}
+	if current != nil {
+		result = append(result, *current)
+	}
+	return result, nil
+}
+
+func parseWindowSize(s string) (int, int, error) {
+	w, h, found := strings.Cut(s, "x")
+	if !found {
+		w, h, found = strings.Cut(s, "X")
+	}
+	if !found {
+		return 0, 0, fmt.Errorf("invalid window size %q, expected WxH", s)
+	}
+	width, e := strconv.Atoi(w)
+	if e != nil {
+		return 0, 0, e
+	}
+	height, e := strconv.Atoi(h)
+	if e != nil {
+		return 0, 0, e
+	}
+	return width, height, nil
+}
+
+func splitCacheSuffix(origin string) (string, bool) {
+	if trimmed, found := strings.CutSuffix(origin, "::cache"); found {
+		return trimmed, true
+	}
+	return origin, false
+}
+
+// diffPNGs decodes two PNGs, resizes the smaller canvas up to match the
+// larger, and writes a red-tinted overlay PNG to outFilePath highlighting
+// every pixel whose squared RGBA distance exceeds a small tolerance.
+func diffPNGs(a, b []byte, outFilePath string) (diffResult, error) {
+	imgA, e := png.Decode(bytes.NewReader(a))
+	if e != nil {
+		return diffResult{}, fmt.Errorf("decode first image: %w", e)
+	}
+	imgB, e := png.Decode(bytes.NewReader(b))
+	if e != nil {
+		return diffResult{}, fmt.Errorf("decode second image: %w", e)
+	}
+
+	boundsA, boundsB := imgA.Bounds(), imgB.Bounds()
+	dimensionsDiffer := boundsA != boundsB
+
+	width := boundsA.Dx()
+	if boundsB.Dx() > width {
+		width = boundsB.Dx()
+	}
+	height := boundsA.Dy()
+	if boundsB.Dy() > height {
+		height = boundsB.Dy()
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	diffPixels := 0
+	const tolerance = 10 * 10 // limit on the squared distance summed over all four channels
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ca := sampleAt(imgA, x, y)
+			cb := sampleAt(imgB, x, y)
+			if squaredDistance(ca, cb) > tolerance {
+				diffPixels++
+				out.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				out.Set(x, y, ca)
+			}
+		}
+	}
+
+	if e := os.MkdirAll(path.Dir(outFilePath), 0755); e != nil {
+		return diffResult{}, e
+	}
+	outFile, e := os.Create(outFilePath)
+	if e != nil {
+		return diffResult{}, e
+	}
+	defer outFile.Close()
+	if e := png.Encode(outFile, out); e != nil {
+		return diffResult{}, e
+	}
+
+	total := width * height
+	percent := 0.0
+	if total > 0 {
+		percent = float64(diffPixels) / float64(total)
+	}
+
+	return diffResult{
+		DimensionsDiffer: dimensionsDiffer,
+		DiffPixels:       diffPixels,
+		TotalPixels:      total,
+		Percent:          percent,
+		OutPath:          outFilePath,
+	}, nil
+}
+
+func sampleAt(img image.Image, x, y int) color.RGBA {
+	b := img.Bounds()
+	if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+		return color.RGBA{}
+	}
+	r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(bl >> 8), A: uint8(a >> 8)}
+}
+
+func squaredDistance(a, b color.RGBA) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	da := int(a.A) - int(b.A)
+	return dr*dr + dg*dg + db*db + da*da
+}
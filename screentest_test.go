@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeScript(t *testing.T, body string) *os.File {
+	t.Helper()
+	f, e := os.CreateTemp(t.TempDir(), "script-*.txt")
+	if e != nil {
+		t.Fatalf("failed to create temp script: %v", e)
+	}
+	if _, e := f.WriteString(body); e != nil {
+		t.Fatalf("failed to write temp script: %v", e)
+	}
+	if _, e := f.Seek(0, 0); e != nil {
+		t.Fatalf("failed to rewind temp script: %v", e)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseScript(t *testing.T) {
+	t.Run("basic testcase", func(t *testing.T) {
+		f := writeScript(t, `
+# comment
+windowsize 800x600
+compare https://a.example.com https://b.example.com::cache
+pathname /foo
+header X-Token: abc
+capture element #main
+eval document.title
+`)
+		cases, e := parseScript(f)
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if len(cases) != 1 {
+			t.Fatalf("got %d testcases, want 1", len(cases))
+		}
+		tc := cases[0]
+		if tc.Origins != [2]string{"https://a.example.com", "https://b.example.com"} {
+			t.Fatalf("unexpected origins: %+v", tc.Origins)
+		}
+		if tc.CacheFlag != [2]bool{false, true} {
+			t.Fatalf("unexpected cache flags: %+v", tc.CacheFlag)
+		}
+		if tc.Path != "/foo" {
+			t.Fatalf("unexpected path: %q", tc.Path)
+		}
+		if tc.Headers.Get("X-Token") != "abc" {
+			t.Fatalf("unexpected headers: %+v", tc.Headers)
+		}
+		if tc.Capture != (captureSpec{Kind: "element", Selector: "#main"}) {
+			t.Fatalf("unexpected capture: %+v", tc.Capture)
+		}
+		if tc.Window != (image.Point{X: 800, Y: 600}) {
+			t.Fatalf("unexpected window: %+v", tc.Window)
+		}
+		if tc.EvalJS != "document.title" {
+			t.Fatalf("unexpected eval js: %q", tc.EvalJS)
+		}
+	})
+
+	t.Run("multiple testcases default window", func(t *testing.T) {
+		f := writeScript(t, `
+compare https://a.example.com https://b.example.com
+pathname /one
+compare https://a.example.com https://b.example.com
+pathname /two
+`)
+		cases, e := parseScript(f)
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if len(cases) != 2 {
+			t.Fatalf("got %d testcases, want 2", len(cases))
+		}
+		if cases[0].Window != (image.Point{X: 1920, Y: 1080}) {
+			t.Fatalf("unexpected default window: %+v", cases[0].Window)
+		}
+		if cases[0].Name != "1" || cases[1].Name != "2" {
+			t.Fatalf("unexpected auto-generated names: %q, %q", cases[0].Name, cases[1].Name)
+		}
+	})
+
+	t.Run("pathname before compare is an error", func(t *testing.T) {
+		f := writeScript(t, "pathname /foo\n")
+		if _, e := parseScript(f); e == nil {
+			t.Fatal("expected error for pathname before compare")
+		}
+	})
+
+	t.Run("unknown keyword is an error", func(t *testing.T) {
+		f := writeScript(t, "bogus wat\n")
+		if _, e := parseScript(f); e == nil {
+			t.Fatal("expected error for unknown keyword")
+		}
+	})
+
+	t.Run("bad windowsize is an error", func(t *testing.T) {
+		f := writeScript(t, "windowsize nope\n")
+		if _, e := parseScript(f); e == nil {
+			t.Fatal("expected error for malformed windowsize")
+		}
+	})
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if e := png.Encode(&buf, img); e != nil {
+		t.Fatalf("failed to encode png: %v", e)
+	}
+	return buf.Bytes()
+}
+
+func TestDiffPNGs(t *testing.T) {
+	t.Run("identical images have no diff", func(t *testing.T) {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				img.Set(x, y, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+			}
+		}
+		a := encodePNG(t, img)
+		outPath := filepath.Join(t.TempDir(), "out.png")
+
+		result, e := diffPNGs(a, a, outPath)
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if result.DiffPixels != 0 || result.Percent != 0 {
+			t.Fatalf("unexpected diff for identical images: %+v", result)
+		}
+		if result.DimensionsDiffer {
+			t.Fatalf("dimensions should match: %+v", result)
+		}
+		if result.TotalPixels != 4 {
+			t.Fatalf("unexpected total pixels: %+v", result)
+		}
+		if _, e := os.Stat(outPath); e != nil {
+			t.Fatalf("expected diff png to be written: %v", e)
+		}
+	})
+
+	t.Run("differing pixel is counted", func(t *testing.T) {
+		imgA := image.NewRGBA(image.Rect(0, 0, 2, 1))
+		imgB := image.NewRGBA(image.Rect(0, 0, 2, 1))
+		imgA.Set(0, 0, color.RGBA{A: 255})
+		imgB.Set(0, 0, color.RGBA{R: 255, A: 255})
+		imgA.Set(1, 0, color.RGBA{A: 255})
+		imgB.Set(1, 0, color.RGBA{A: 255})
+
+		outPath := filepath.Join(t.TempDir(), "out.png")
+		result, e := diffPNGs(encodePNG(t, imgA), encodePNG(t, imgB), outPath)
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if result.DiffPixels != 1 {
+			t.Fatalf("got %d diff pixels, want 1", result.DiffPixels)
+		}
+		if result.Percent != 0.5 {
+			t.Fatalf("got %v percent, want 0.5", result.Percent)
+		}
+	})
+
+	t.Run("mismatched dimensions are reported", func(t *testing.T) {
+		imgA := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		imgB := image.NewRGBA(image.Rect(0, 0, 3, 2))
+
+		outPath := filepath.Join(t.TempDir(), "out.png")
+		result, e := diffPNGs(encodePNG(t, imgA), encodePNG(t, imgB), outPath)
+		if e != nil {
+			t.Fatalf("unexpected error: %v", e)
+		}
+		if !result.DimensionsDiffer {
+			t.Fatalf("expected DimensionsDiffer to be true: %+v", result)
+		}
+		if result.TotalPixels != 6 {
+			t.Fatalf("unexpected total pixels: %+v", result)
+		}
+	})
+
+	t.Run("invalid png is an error", func(t *testing.T) {
+		if _, e := diffPNGs([]byte("not a png"), []byte("not a png"), filepath.Join(t.TempDir(), "out.png")); e == nil {
+			t.Fatal("expected error for invalid png")
+		}
+	})
+}